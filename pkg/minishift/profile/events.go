@@ -0,0 +1,228 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profile
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+	"github.com/minishift/minishift/pkg/minishift/constants"
+	utilos "github.com/minishift/minishift/pkg/util/os"
+	"github.com/minishift/minishift/pkg/util/slice"
+)
+
+// EventType identifies the kind of change reported on the channel
+// returned by Events().
+type EventType int
+
+const (
+	ProfileAdded EventType = iota
+	ProfileRemoved
+	StateChanged
+)
+
+// State is the running state of a profile's underlying machine.
+type State int
+
+const (
+	Missing State = iota
+	Running
+	Stopped
+)
+
+// Event is a single change reported by Events(). State is only meaningful
+// on a StateChanged event.
+type Event struct {
+	Type  EventType
+	Name  string
+	State State
+}
+
+func profilesDir() string {
+	return filepath.Join(constants.Minipath, "profiles")
+}
+
+func profileDir(name string) string {
+	return filepath.Join(profilesDir(), name)
+}
+
+func machinesDir(name string) string {
+	return filepath.Join(profileDir(name), "machines")
+}
+
+// Events watches $MINISHIFT_HOME/profiles for profiles being added or
+// removed, and each profile's machine state file for state changes, and
+// reports them on the returned channel. This replaces having callers poll
+// GetProfileList() and shell out to "minishift status" for every profile
+// on a timer. If fsnotify cannot be set up, Events falls back to a slow
+// poll so consumers still eventually see changes.
+func Events() <-chan Event {
+	out := make(chan Event)
+	go watch(out)
+	return out
+}
+
+func watch(out chan<- Event) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		glog.Warningf("profile: fsnotify unavailable (%v), falling back to polling", err)
+		pollFallback(out)
+		return
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(profilesDir()); err != nil {
+		glog.Warningf("profile: could not watch %s (%v), falling back to polling", profilesDir(), err)
+		pollFallback(out)
+		return
+	}
+
+	known := map[string]bool{}
+	for _, name := range GetProfileList() {
+		known[name] = true
+		watchMachineState(watcher, name)
+		out <- Event{Type: StateChanged, Name: name, State: readState(name)}
+	}
+
+	for {
+		select {
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			handleFsEvent(watcher, ev, known, out)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			glog.Warningf("profile: fsnotify error: %v", err)
+		}
+	}
+}
+
+func handleFsEvent(watcher *fsnotify.Watcher, ev fsnotify.Event, known map[string]bool, out chan<- Event) {
+	name := filepath.Base(ev.Name)
+
+	if filepath.Dir(ev.Name) == profilesDir() {
+		switch {
+		case ev.Op&fsnotify.Create != 0:
+			if !known[name] {
+				known[name] = true
+				watchMachineState(watcher, name)
+				out <- Event{Type: ProfileAdded, Name: name}
+			}
+		case ev.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+			if known[name] {
+				delete(known, name)
+				out <- Event{Type: ProfileRemoved, Name: name}
+			}
+		}
+		return
+	}
+
+	// The profile's "machines" subdirectory doesn't exist until
+	// "minishift start" creates it, so watchMachineState couldn't watch
+	// it at profile-add time. Catch its creation here, on the profile's
+	// own directory, and add the real watch once it shows up.
+	if name == "machines" && ev.Op&fsnotify.Create != 0 {
+		profileName := filepath.Base(filepath.Dir(ev.Name))
+		if known[profileName] {
+			if err := watcher.Add(machinesDir(profileName)); err != nil {
+				glog.V(3).Infof("profile: could not watch %s: %v", machinesDir(profileName), err)
+			}
+			out <- Event{Type: StateChanged, Name: profileName, State: readState(profileName)}
+		}
+		return
+	}
+
+	if ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+		return
+	}
+	if profileName, ok := profileForMachinesDir(filepath.Dir(ev.Name)); ok && known[profileName] {
+		out <- Event{Type: StateChanged, Name: profileName, State: readState(profileName)}
+	}
+}
+
+// watchMachineState adds a watch on a profile's own directory, so the
+// later creation of its "machines" subdirectory by "minishift start" is
+// observed, and on the machines directory itself when it already
+// exists, so writes to its libmachine state file are reported without
+// having to poll it.
+func watchMachineState(watcher *fsnotify.Watcher, name string) {
+	if err := watcher.Add(profileDir(name)); err != nil {
+		glog.V(3).Infof("profile: could not watch %s: %v", profileDir(name), err)
+	}
+	if err := watcher.Add(machinesDir(name)); err != nil {
+		glog.V(3).Infof("profile: could not watch %s: %v", machinesDir(name), err)
+	}
+}
+
+func profileForMachinesDir(dir string) (string, bool) {
+	name := filepath.Base(filepath.Dir(dir))
+	if dir == machinesDir(name) {
+		return name, true
+	}
+	return "", false
+}
+
+// readState shells out to "minishift status" for a single profile. With
+// fsnotify it is called once per known profile at startup and then only
+// when the profile's machine state changes on disk; pollFallback calls it
+// for every known profile on each poll.
+func readState(name string) State {
+	cmd, _ := utilos.CurrentExecutable()
+	out, _ := exec.Command(cmd, "status", "--profile", name).Output()
+	stdOut := string(out)
+
+	switch {
+	case strings.Contains(stdOut, "Running"):
+		return Running
+	case strings.Contains(stdOut, "Stopped"):
+		return Stopped
+	default:
+		return Missing
+	}
+}
+
+// pollFallback emulates Events() by polling, for platforms where fsnotify
+// isn't available.
+func pollFallback(out chan<- Event) {
+	known := map[string]State{}
+	for {
+		current := GetProfileList()
+		for _, name := range current {
+			state := readState(name)
+			if _, ok := known[name]; !ok {
+				out <- Event{Type: ProfileAdded, Name: name}
+			} else if known[name] != state {
+				out <- Event{Type: StateChanged, Name: name, State: state}
+			}
+			known[name] = state
+		}
+		for name := range known {
+			if exists, _ := slice.ItemExists(current, name); !exists {
+				delete(known, name)
+				out <- Event{Type: ProfileRemoved, Name: name}
+			}
+		}
+		time.Sleep(20 * time.Second)
+	}
+}
@@ -0,0 +1,253 @@
+// +build !systemtray
+
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systemtray
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anjannath/systray"
+	"github.com/golang/glog"
+	"github.com/minishift/minishift/pkg/minishift/constants"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// trayConfigFile is the name of the optional per-user config file that
+// declares additional menu entries for each profile's submenu.
+const trayConfigFile = "tray.yml"
+
+// MenuItemConfig describes a single user-defined menu entry declared under
+// a profile in tray.yml. Command may reference "{{profile}}", which is
+// replaced with the owning profile's name before execution.
+type MenuItemConfig struct {
+	Name           string   `yaml:"name"`
+	Command        string   `yaml:"command"`
+	UpdateInterval duration `yaml:"update_interval"`
+	Timeout        duration `yaml:"timeout"`
+}
+
+// duration is a time.Duration that unmarshals from the "30s"-style
+// strings users actually write in tray.yml. time.Duration has no
+// UnmarshalYAML of its own, so yaml.v2 can't coerce such a scalar into
+// the int64 it wraps without this.
+type duration time.Duration
+
+func (d *duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// ProfileMenuConfig is the list of custom menu entries declared for a
+// single profile.
+type ProfileMenuConfig struct {
+	Items []MenuItemConfig `yaml:"items"`
+}
+
+// TrayConfig is the root of tray.yml, keyed by profile name.
+type TrayConfig struct {
+	Profiles map[string]ProfileMenuConfig `yaml:"profiles"`
+}
+
+var (
+	trayConfig     = &TrayConfig{}
+	trayConfigLock sync.RWMutex
+)
+
+func trayConfigPath() string {
+	return filepath.Join(constants.Minipath, trayConfigFile)
+}
+
+// loadTrayConfig reads and parses tray.yml. A missing file is not an error,
+// it simply means no custom menu entries were declared.
+func loadTrayConfig() (*TrayConfig, error) {
+	data, err := ioutil.ReadFile(trayConfigPath())
+	if os.IsNotExist(err) {
+		return &TrayConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg TrayConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// reloadTrayConfigIfChanged loads tray.yml and, if it differs from the
+// currently applied config, rebuilds the dynamic menu entries of every
+// known profile submenu. It is polled on a timer from consumeProfileEvents
+// so that editing tray.yml takes effect without restarting the tray.
+func reloadTrayConfigIfChanged() {
+	cfg, err := loadTrayConfig()
+	if err != nil {
+		glog.Errorf("tray: could not load %s: %v", trayConfigPath(), err)
+		return
+	}
+
+	trayConfigLock.Lock()
+	changed := !reflect.DeepEqual(cfg, trayConfig)
+	if changed {
+		trayConfig = cfg
+	}
+	trayConfigLock.Unlock()
+
+	if !changed {
+		return
+	}
+
+	submenusToMenuItemsLock.Lock()
+	defer submenusToMenuItemsLock.Unlock()
+	for profileName, ma := range submenusToMenuItems {
+		submenusLock.RLock()
+		submenu, ok := submenus[profileName]
+		submenusLock.RUnlock()
+		if !ok {
+			continue
+		}
+		for _, d := range ma.dynamic {
+			close(d.stop)
+			d.item.Hide()
+		}
+		ma.dynamic = addDynamicMenuItems(submenu, profileName, cfg.Profiles[profileName].Items)
+		submenusToMenuItems[profileName] = ma
+	}
+}
+
+// dynamicMenuItem is a single user-configured submenu entry rendered
+// alongside Start/Stop, optionally polling its command on an interval to
+// refresh its label. stop is closed to tear down its click/update
+// goroutines when tray.yml changes and the item is rebuilt or dropped.
+type dynamicMenuItem struct {
+	item   *systray.MenuItem
+	config MenuItemConfig
+	stop   chan struct{}
+
+	busyLock sync.Mutex
+	busy     bool
+}
+
+// addDynamicMenuItems builds the configured custom entries under submenu
+// and starts their click/update handlers.
+func addDynamicMenuItems(submenu *systray.MenuItem, profileName string, items []MenuItemConfig) []*dynamicMenuItem {
+	var dynamicItems []*dynamicMenuItem
+	for _, cfg := range items {
+		menuItem := submenu.AddSubMenuItem(cfg.Name, "", 0)
+		d := &dynamicMenuItem{item: menuItem, config: cfg, stop: make(chan struct{})}
+		dynamicItems = append(dynamicItems, d)
+
+		go handleDynamicMenuItemClicks(d, profileName)
+		if cfg.UpdateInterval > 0 {
+			go updateDynamicMenuItemLabel(d, profileName)
+		}
+	}
+	return dynamicItems
+}
+
+// runDynamicCommand runs a custom menu entry's command, refusing to start
+// a second run while one is already in flight, and killing the command if
+// it runs past its configured timeout.
+func runDynamicCommand(d *dynamicMenuItem, profileName string) (string, error) {
+	d.busyLock.Lock()
+	if d.busy {
+		d.busyLock.Unlock()
+		return "", fmt.Errorf("%s is still running", d.config.Name)
+	}
+	d.busy = true
+	d.busyLock.Unlock()
+
+	defer func() {
+		d.busyLock.Lock()
+		d.busy = false
+		d.busyLock.Unlock()
+	}()
+
+	args := strings.Fields(strings.Replace(d.config.Command, "{{profile}}", profileName, -1))
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty command for %s", d.config.Name)
+	}
+	command := exec.Command(args[0], args[1:]...)
+
+	if d.config.Timeout <= 0 {
+		out, err := command.Output()
+		return strings.TrimSpace(string(out)), err
+	}
+
+	var out strings.Builder
+	command.Stdout = &out
+	if err := command.Start(); err != nil {
+		return "", err
+	}
+	done := make(chan error, 1)
+	go func() { done <- command.Wait() }()
+
+	select {
+	case err := <-done:
+		return strings.TrimSpace(out.String()), err
+	case <-time.After(time.Duration(d.config.Timeout)):
+		command.Process.Kill()
+		return "", fmt.Errorf("%s timed out after %s", d.config.Name, time.Duration(d.config.Timeout))
+	}
+}
+
+func handleDynamicMenuItemClicks(d *dynamicMenuItem, profileName string) {
+	for {
+		select {
+		case <-d.item.OnClickCh():
+			if _, err := runDynamicCommand(d, profileName); err != nil {
+				glog.Errorf("tray: running %q for profile %s failed: %v", d.config.Name, profileName, err)
+			}
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+func updateDynamicMenuItemLabel(d *dynamicMenuItem, profileName string) {
+	for {
+		out, err := runDynamicCommand(d, profileName)
+		if err != nil {
+			d.item.SetTitle(fmt.Sprintf("%s: error", d.config.Name))
+		} else if out != "" {
+			d.item.SetTitle(fmt.Sprintf("%s: %s", d.config.Name, out))
+		}
+
+		select {
+		case <-time.After(time.Duration(d.config.UpdateInterval)):
+		case <-d.stop:
+			return
+		}
+	}
+}
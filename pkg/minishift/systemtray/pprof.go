@@ -0,0 +1,86 @@
+// +build pprof
+
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systemtray
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/golang/glog"
+	"github.com/minishift/minishift/pkg/util/atexit"
+)
+
+// pprofDirEnv names the environment variable that selects the directory
+// profiles are written to when the tray is built with -tags pprof.
+const pprofDirEnv = "MINISHIFT_TRAY_PPROF_DIR"
+
+// startProfiling starts CPU, heap, block and mutex profiling and registers
+// an atexit handler that flushes them to MINISHIFT_TRAY_PPROF_DIR (or
+// os.TempDir() if unset) before the process exits. The tray's many
+// long-lived goroutines otherwise never return, so profiles must be
+// written on shutdown rather than at the end of a function.
+func startProfiling() {
+	dir := os.Getenv(pprofDirEnv)
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		glog.Errorf("pprof: could not create %s: %v", dir, err)
+		return
+	}
+
+	cpuFile, err := os.Create(filepath.Join(dir, "cpu.pprof"))
+	if err != nil {
+		glog.Errorf("pprof: could not create cpu profile: %v", err)
+		return
+	}
+	if err := pprof.StartCPUProfile(cpuFile); err != nil {
+		glog.Errorf("pprof: could not start cpu profile: %v", err)
+	}
+
+	runtime.SetBlockProfileRate(1)
+	runtime.SetMutexProfileFraction(1)
+
+	atexit.Register(func() {
+		pprof.StopCPUProfile()
+		cpuFile.Close()
+
+		writeProfile(dir, "heap", true)
+		writeProfile(dir, "block", false)
+		writeProfile(dir, "mutex", false)
+	})
+}
+
+func writeProfile(dir, name string, gc bool) {
+	f, err := os.Create(filepath.Join(dir, name+".pprof"))
+	if err != nil {
+		glog.Errorf("pprof: could not create %s profile: %v", name, err)
+		return
+	}
+	defer f.Close()
+
+	if gc {
+		runtime.GC()
+	}
+	if err := pprof.Lookup(name).WriteTo(f, 0); err != nil {
+		glog.Errorf("pprof: could not write %s profile: %v", name, err)
+	}
+}
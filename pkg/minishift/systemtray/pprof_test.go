@@ -0,0 +1,56 @@
+// +build pprof
+
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systemtray
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minishift/minishift/pkg/util/atexit"
+)
+
+func TestStartProfilingWritesNonEmptyProfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "minishift-tray-pprof")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv(pprofDirEnv, dir)
+	defer os.Unsetenv(pprofDirEnv)
+
+	startProfiling()
+
+	// Fire runs the handler startProfiling registered without calling
+	// os.Exit, simulating a clean shutdown of the test binary.
+	atexit.Fire()
+
+	for _, name := range []string{"cpu.pprof", "heap.pprof", "block.pprof", "mutex.pprof"} {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("expected %s to exist: %v", name, err)
+			continue
+		}
+		if fi.Size() == 0 {
+			t.Errorf("expected %s to be non-empty", name)
+		}
+	}
+}
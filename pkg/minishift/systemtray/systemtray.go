@@ -19,22 +19,24 @@ limitations under the License.
 package systemtray
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	goos "os"
 	"os/exec"
-	"path/filepath"
-	"runtime"
+	"os/signal"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/anjannath/systray"
 	"github.com/golang/glog"
 	"github.com/minishift/minishift/pkg/minishift/profile"
-	"github.com/minishift/minishift/pkg/minishift/shell/powershell"
 	"github.com/minishift/minishift/pkg/minishift/systemtray/icon"
+	"github.com/minishift/minishift/pkg/util/atexit"
+	"github.com/minishift/minishift/pkg/util/child"
 	"github.com/minishift/minishift/pkg/util/os"
-	"github.com/minishift/minishift/pkg/util/slice"
 )
 
 const (
@@ -44,16 +46,13 @@ const (
 )
 
 const (
-	DOES_NOT_EXIST int = iota
-	RUNNING
-	STOPPED
-	START_PROFILE
+	START_PROFILE int = iota
 	STOP_PROFILE
 )
 
 var (
 	submenus            = make(map[string]*systray.MenuItem)
-	submenusToMenuItems = make(map[string]MenuAction)
+	submenusToMenuItems = make(map[string]*MenuAction)
 
 	profiles        []string
 	profileMenuList []*systray.MenuItem
@@ -62,22 +61,44 @@ var (
 	submenusToMenuItemsLock sync.RWMutex
 )
 
+// MenuAction holds a profile's Start/Stop menu items and any dynamic
+// entries declared for it in tray.yml. busy guards against starting a
+// second start/stop while one is already in flight.
 type MenuAction struct {
-	start *systray.MenuItem
-	stop  *systray.MenuItem
+	start   *systray.MenuItem
+	stop    *systray.MenuItem
+	dynamic []*dynamicMenuItem
+
+	busyLock sync.Mutex
+	busy     bool
 }
 
 func OnReady() {
+	startProfiling()
+
+	sigCh := make(chan goos.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		atexit.Exit(0)
+	}()
+
 	systray.SetIcon(icon.TrayIcon)
 	exit := systray.AddMenuItem(EXIT, "", 0)
 	systray.AddSeparator()
 	profiles = profile.GetProfileList()
-	for _, profile := range profiles {
-		submenu := systray.AddSubMenu(strings.Title(profile))
-		startMenu := submenu.AddSubMenuItem(START, "", 0)
-		stopMenu := submenu.AddSubMenuItem(STOP, "", 0)
-		submenus[profile] = submenu
-		submenusToMenuItems[profile] = MenuAction{start: startMenu, stop: stopMenu}
+
+	cfg, err := loadTrayConfig()
+	if err != nil {
+		glog.Errorf("tray: could not load %s: %v", trayConfigPath(), err)
+		cfg = &TrayConfig{}
+	}
+	trayConfigLock.Lock()
+	trayConfig = cfg
+	trayConfigLock.Unlock()
+
+	for _, name := range profiles {
+		addProfileToTray(name)
 	}
 
 	go func() {
@@ -85,234 +106,218 @@ func OnReady() {
 		systray.Quit()
 	}()
 
-	for k, v := range submenusToMenuItems {
-		go startStopHandler(icon.Running, k, v.start, START_PROFILE)
-		go startStopHandler(icon.Stopped, k, v.stop, STOP_PROFILE)
-	}
-
-	go addNewProfilesToTray()
-
-	go removeDeletedProfilesFromTray()
-
-	go updateProfileStatus()
+	go consumeProfileEvents()
 }
 
+// OnExit runs when the systray library shuts down the tray (e.g. after the
+// Exit menu item is clicked). It routes through atexit.Exit so that any
+// registered shutdown handlers, such as pprof profile flushing, always run
+// before the process terminates.
 func OnExit() {
-	return
+	atexit.Exit(0)
 }
 
-func getStatus(profileName string) int {
-	cmd, _ := os.CurrentExecutable()
-	args := []string{"status", "--profile", profileName}
-	command := exec.Command(cmd, args...)
-	out, _ := command.Output()
-	stdOut := fmt.Sprintf("%s", out)
+// consumeProfileEvents replaces the old fixed-interval polling of the
+// profile list and of each profile's status with a single consumer of
+// profile.Events(), so the tray reacts to a profile being added, removed
+// or changing state as soon as it happens instead of on the next tick.
+// tray.yml is still checked for changes on a timer, since it has no
+// filesystem watch of its own.
+func consumeProfileEvents() {
+	configTicker := time.NewTicker(40 * time.Second)
+	defer configTicker.Stop()
+
+	events := profile.Events()
+	for {
+		select {
+		case ev := <-events:
+			switch ev.Type {
+			case profile.ProfileAdded:
+				addProfileToTray(ev.Name)
+			case profile.ProfileRemoved:
+				removeProfileFromTray(ev.Name)
+			case profile.StateChanged:
+				applyProfileState(ev.Name, ev.State)
+			}
+		case <-configTicker.C:
+			reloadTrayConfigIfChanged()
+		}
+	}
+}
 
-	if strings.Contains(stdOut, "Running") {
-		return RUNNING
+// addProfileToTray builds a profile's submenu, its Start/Stop items and
+// any dynamic items declared for it in tray.yml. It is a no-op if the
+// profile already has a submenu.
+func addProfileToTray(name string) {
+	submenusLock.Lock()
+	if _, ok := submenus[name]; ok {
+		submenusLock.Unlock()
+		return
 	}
+	submenu := systray.AddSubMenu(strings.Title(name))
+	submenus[name] = submenu
+	submenusLock.Unlock()
 
-	if strings.Contains(stdOut, "Stopped") {
-		return STOPPED
+	startMenu := submenu.AddSubMenuItem(START, "", 0)
+	stopMenu := submenu.AddSubMenuItem(STOP, "", 0)
+
+	trayConfigLock.RLock()
+	dynamic := addDynamicMenuItems(submenu, name, trayConfig.Profiles[name].Items)
+	trayConfigLock.RUnlock()
+
+	ma := &MenuAction{start: startMenu, stop: stopMenu, dynamic: dynamic}
+	submenusToMenuItemsLock.Lock()
+	submenusToMenuItems[name] = ma
+	submenusToMenuItemsLock.Unlock()
+
+	go startStopHandler(name, ma, START_PROFILE)
+	go startStopHandler(name, ma, STOP_PROFILE)
+}
+
+// removeProfileFromTray hides and forgets a deleted profile's submenu.
+func removeProfileFromTray(name string) {
+	submenusLock.Lock()
+	if submenu, ok := submenus[name]; ok {
+		submenu.Hide()
+		delete(submenus, name)
 	}
-	return DOES_NOT_EXIST
+	submenusLock.Unlock()
+
+	submenusToMenuItemsLock.Lock()
+	delete(submenusToMenuItems, name)
+	submenusToMenuItemsLock.Unlock()
 }
 
-// Add newly created profiles to the tray
-func addNewProfilesToTray() {
-	for {
-		time.Sleep(40 * time.Second)
+// applyProfileState updates a profile submenu's bitmap to reflect the
+// state of its machine: green running, red stopped, grey does not exist.
+func applyProfileState(name string, state profile.State) {
+	submenusLock.Lock()
+	defer submenusLock.Unlock()
 
-		newProfilesList := profile.GetProfileList()
-		for _, profile := range newProfilesList {
-			submenusLock.Lock()
-			if _, ok := submenus[profile]; ok {
-				submenusLock.Unlock()
-				continue
-			} else {
-				submenu := systray.AddSubMenu(strings.Title(profile))
-				submenus[profile] = submenu
-				submenusLock.Unlock()
-				startMenu := submenu.AddSubMenuItem(START, "", 0)
-				stopMenu := submenu.AddSubMenuItem(STOP, "", 0)
-				submenusToMenuItemsLock.Lock()
-				ma := MenuAction{start: startMenu, stop: stopMenu}
-				submenusToMenuItems[profile] = ma
-				submenusToMenuItemsLock.Unlock()
-
-				go startStopHandler(icon.Running, profile, ma.start, START_PROFILE)
-
-				go startStopHandler(icon.Stopped, profile, ma.stop, STOP_PROFILE)
-			}
-		}
+	submenu, ok := submenus[name]
+	if !ok {
+		return
+	}
+	switch state {
+	case profile.Running:
+		submenu.AddBitmap(icon.Running)
+	case profile.Stopped:
+		submenu.AddBitmap(icon.Stopped)
+	case profile.Missing:
+		submenu.AddBitmap(icon.DoesNotExist)
 	}
 }
 
-// Remove deleted profiles from tray
-func removeDeletedProfilesFromTray() {
-	for {
-		time.Sleep(30 * time.Second)
-		newProfileList := profile.GetProfileList()
-		for k := range submenus {
-			submenusLock.Lock()
-			if exists, _ := slice.ItemExists(newProfileList, k); exists {
-				submenusLock.Unlock()
-				continue
-			} else {
-				submenus[k].Hide()
-				delete(submenus, k)
-				submenusLock.Unlock()
-				if _, ok := submenusToMenuItems[k]; ok {
-					submenusToMenuItemsLock.Lock()
-					delete(submenusToMenuItems, k)
-					submenusToMenuItemsLock.Unlock()
-				}
-			}
-		}
+// startStopHandler waits for clicks on a profile's Start or Stop item and
+// runs the corresponding minishift subcommand. While a run is in flight
+// for this profile, both items are disabled and a later click is
+// ignored, instead of queuing a second overlapping run.
+func startStopHandler(profileName string, ma *MenuAction, action int) {
+	var item *systray.MenuItem
+	var subcommand string
+	var successIcon []byte
+	if action == START_PROFILE {
+		item, subcommand, successIcon = ma.start, "start", icon.Running
+	} else {
+		item, subcommand, successIcon = ma.stop, "stop", icon.Stopped
 	}
-}
 
-// stopProfile stops a profile when clicked on the stop menuItem
-func stopProfile(profileName string) error {
-	minishiftBinary, _ := os.CurrentExecutable()
-	if runtime.GOOS == "windows" {
-		var stopCommandString = fmt.Sprintf(minishiftBinary + " stop --profile " + profileName)
-		stopFilePath := filepath.Join(goos.TempDir(), "minishift_stop.bat")
+	for {
+		<-item.OnClickCh()
 
-		f, err := goos.Create(stopFilePath)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		if _, err = f.WriteString(stopCommandString); err != nil {
-			return err
+		ma.busyLock.Lock()
+		if ma.busy {
+			ma.busyLock.Unlock()
+			continue
 		}
-		f.Close()
+		ma.busy = true
+		ma.busyLock.Unlock()
 
-		posh := powershell.New()
-		command := fmt.Sprintf("`Start-Process -FilePath %s", stopFilePath)
-		_, _, err = posh.Execute(command)
-		return err
-	}
+		ma.start.Disable()
+		ma.stop.Disable()
 
-	if runtime.GOOS == "darwin" {
-		var stopCommandString = fmt.Sprintf(minishiftBinary + " stop --profile " + profileName)
-		stopFilePath := filepath.Join(goos.TempDir(), "minishift.stop")
+		runProfileCommand(profileName, subcommand, successIcon)
 
-		f, err := goos.Create(stopFilePath)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		if _, err = f.WriteString(stopCommandString); err != nil {
-			return err
-		}
-		if err = f.Chmod(0744); err != nil {
-			return err
-		}
-		f.Close()
-		args := []string{"-F", "-a", "Terminal.app", stopFilePath}
-		cmd, err := exec.LookPath("open")
-		if err != nil {
-			if glog.V(3) {
-				fmt.Println("Could not find open in path")
-				return fmt.Errorf("%v", err)
-			}
-		}
-		command := exec.Command(cmd, args...)
-		return command.Run()
+		ma.start.Enable()
+		ma.stop.Enable()
+
+		ma.busyLock.Lock()
+		ma.busy = false
+		ma.busyLock.Unlock()
 	}
-	return nil
 }
 
-// startProfile starts a profile when clicked on the start menuItem
-func startProfile(profileName string) error {
+// runProfileCommand runs "minishift <subcommand> --profile <name>" as a
+// direct child of the tray, reaped through pkg/util/child, instead of
+// writing a temp-file wrapper and handing it to Terminal.app/PowerShell
+// and assuming success whenever that launch call returned nil. While it
+// runs, the profile's submenu shows a spinner; afterwards it shows
+// successIcon or icon.Error, with the tooltip carrying the last error.
+func runProfileCommand(profileName, subcommand string, successIcon []byte) {
 	minishiftBinary, _ := os.CurrentExecutable()
-	if runtime.GOOS == "windows" {
-		var startCommandString = fmt.Sprintf(minishiftBinary + " start --profile " + profileName)
-		startFilePath := filepath.Join(goos.TempDir(), "minishift_start.bat")
+	cmd := exec.Command(minishiftBinary, subcommand, "--profile", profileName)
 
-		f, err := goos.Create(startFilePath)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		if _, err = f.WriteString(startCommandString); err != nil {
-			return err
-		}
-		f.Close()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		glog.Errorf("%s --profile %s: %v", subcommand, profileName, err)
+		return
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		glog.Errorf("%s --profile %s: %v", subcommand, profileName, err)
+		return
+	}
+	go streamToLog(subcommand, profileName, stdout, false)
+	go streamToLog(subcommand, profileName, stderr, true)
 
-		posh := powershell.New()
-		command := fmt.Sprintf("Start-Process -FilePath %s", startFilePath)
-		_, _, err = posh.Execute(command)
-		return err
+	submenusLock.RLock()
+	submenu, ok := submenus[profileName]
+	submenusLock.RUnlock()
+
+	var stopSpinner func()
+	if ok {
+		stopSpinner = icon.Spinner.Play(func(frame []byte) {
+			submenusLock.Lock()
+			submenu.AddBitmap(frame)
+			submenusLock.Unlock()
+		})
 	}
-	if runtime.GOOS == "darwin" {
-		var startCommandString = fmt.Sprintf(minishiftBinary + " start --profile " + profileName)
-		startFilePath := filepath.Join(goos.TempDir(), "minishift.start")
 
-		f, err := goos.Create(startFilePath)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		if _, err = f.WriteString(startCommandString); err != nil {
-			return err
+	resultCh, err := child.Watch(cmd)
+	if err != nil {
+		if stopSpinner != nil {
+			stopSpinner()
 		}
-		if err = f.Chmod(0744); err != nil {
-			return err
-		}
-		f.Close()
-
-		args := []string{"-F", "-a", "Terminal.app", startFilePath}
-		cmd, err := exec.LookPath("open")
-		if err != nil {
-			if glog.V(3) {
-				fmt.Println("Could not find open in path")
-				return fmt.Errorf("%v", err)
-			}
-		}
-		command := exec.Command(cmd, args...)
-		return command.Run()
+		glog.Errorf("%s --profile %s: %v", subcommand, profileName, err)
+		return
 	}
-	return nil
-}
+	result := <-resultCh
 
-// updateProfileStatus updates the menu bitmap to reflact the state of
-// machine, green: running, red: stoppped, grey: does not exist
-func updateProfileStatus() {
-	for {
-		time.Sleep(20 * time.Second)
-		submenusLock.Lock()
-		for k, v := range submenus {
-			status := getStatus(k)
-			if status == DOES_NOT_EXIST {
-				v.AddBitmap(icon.DoesNotExist)
-			}
-			if status == RUNNING {
-				v.AddBitmap(icon.Running)
-			}
-			if status == STOPPED {
-				v.AddBitmap(icon.Stopped)
-			}
-		}
-		submenusLock.Unlock()
+	if stopSpinner != nil {
+		stopSpinner()
+	}
+	if !ok {
+		return
+	}
+
+	submenusLock.Lock()
+	if result.Err != nil || result.ExitCode != 0 {
+		submenu.AddBitmap(icon.Error)
+		submenu.SetTooltip(fmt.Sprintf("%s --profile %s exited %d: %v", subcommand, profileName, result.ExitCode, result.Err))
+	} else {
+		submenu.AddBitmap(successIcon)
+		submenu.SetTooltip("")
 	}
+	submenusLock.Unlock()
 }
 
-func startStopHandler(iconData []byte, submenu string, m *systray.MenuItem, action int) {
-	var err error
-	for {
-		<-m.OnClickCh()
-		if action == START_PROFILE {
-			err = startProfile(submenu)
+func streamToLog(subcommand, profileName string, r io.Reader, isErr bool) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if isErr {
+			glog.Errorf("%s --profile %s: %s", subcommand, profileName, scanner.Text())
 		} else {
-			err = stopProfile(submenu)
-		}
-		if err == nil {
-			submenusLock.Lock()
-			submenus[submenu].AddBitmap(iconData)
-			submenusLock.Unlock()
+			glog.V(2).Infof("%s --profile %s: %s", subcommand, profileName, scanner.Text())
 		}
 	}
 }
@@ -0,0 +1,63 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package atexit provides a single place for long-running daemons (like the
+// systray) to register cleanup handlers that must run before the process
+// exits, regardless of which code path triggers the exit.
+package atexit
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	handlersLock sync.Mutex
+	handlers     []func()
+)
+
+// Register adds f to the list of handlers that Exit runs before the
+// process terminates. Handlers run in the reverse order they were
+// registered, most-recently-registered first.
+func Register(f func()) {
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+	handlers = append(handlers, f)
+}
+
+// Exit runs every registered handler and then terminates the process with
+// the given status code. All shutdown paths (menu actions, signal
+// handlers) must call this instead of os.Exit directly so that registered
+// handlers, such as pprof profile flushing, always run.
+func Exit(code int) {
+	Fire()
+	os.Exit(code)
+}
+
+// Fire runs every registered handler, most-recently-registered first,
+// without terminating the process. Exit calls it before os.Exit; tests
+// that need to simulate a clean shutdown without killing the test binary
+// can call it directly.
+func Fire() {
+	handlersLock.Lock()
+	toRun := make([]func(), len(handlers))
+	copy(toRun, handlers)
+	handlersLock.Unlock()
+
+	for i := len(toRun) - 1; i >= 0; i-- {
+		toRun[i]()
+	}
+}
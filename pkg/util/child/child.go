@@ -0,0 +1,27 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package child starts and reaps direct child processes for callers
+// (like the systray) that need to know a command's real outcome instead
+// of assuming success whenever launching it returned nil.
+package child
+
+// Result is the outcome of a child process started via Watch.
+type Result struct {
+	Pid      int
+	ExitCode int
+	Err      error
+}
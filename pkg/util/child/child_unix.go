@@ -0,0 +1,59 @@
+// +build !windows
+
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package child
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Watch starts cmd and returns a channel that receives its exit result
+// once cmd.Wait returns, from a dedicated per-process goroutine.
+//
+// This used to install a single process-wide SIGCHLD handler that reaped
+// any exited child with Wait4(-1, ...), but that raced every other
+// exec.Command(...).Run/Output/Wait call in the process: if the reaper
+// won the race for a pid some other caller's Wait() was also blocked on,
+// that Wait() got ECHILD even though the child had run fine. Calling
+// cmd.Wait() here, like child_windows.go already did, avoids the race
+// and lets the standard os/exec pipe cleanup run.
+func Watch(cmd *exec.Cmd) (<-chan Result, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Result, 1)
+	pid := cmd.Process.Pid
+	go func() {
+		err := cmd.Wait()
+		exitCode := 0
+		if err != nil {
+			exitCode = -1
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+					exitCode = ws.ExitStatus()
+				}
+			}
+		}
+		ch <- Result{Pid: pid, ExitCode: exitCode, Err: err}
+		close(ch)
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,56 @@
+// +build windows
+
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package child
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Watch starts cmd and returns a channel that receives its exit result
+// once cmd.Wait returns, from a dedicated goroutine per process. As in
+// child_unix.go, each watched process gets its own waiter; there is no
+// process-wide reaper, and Windows has no SIGCHLD to drive one. cmd.Wait,
+// rather than the lower-level cmd.Process.Wait, is what closes the
+// StdoutPipe/StderrPipe file descriptors callers like runProfileCommand
+// open on cmd.
+func Watch(cmd *exec.Cmd) (<-chan Result, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Result, 1)
+	pid := cmd.Process.Pid
+	go func() {
+		err := cmd.Wait()
+		exitCode := 0
+		if err != nil {
+			exitCode = -1
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+					exitCode = ws.ExitStatus()
+				}
+			}
+		}
+		ch <- Result{Pid: pid, ExitCode: exitCode, Err: err}
+		close(ch)
+	}()
+
+	return ch, nil
+}